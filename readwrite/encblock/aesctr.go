@@ -0,0 +1,47 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package encblock
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+// AESCTRCipher decrypts an EncBlock with AES in CTR mode. The IV is
+// derived from the block's VA and FileOffset, zero-padded out to the
+// AES block size.
+type AESCTRCipher struct{}
+
+func (AESCTRCipher) Decrypt(dst, src []byte, block readwrite.EncBlock, key []byte) error {
+	blockCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	binary.LittleEndian.PutUint32(iv[0:4], block.VA)
+	binary.LittleEndian.PutUint32(iv[4:8], block.FileOffset)
+
+	cipher.NewCTR(blockCipher, iv).XORKeyStream(dst, src)
+
+	return nil
+}
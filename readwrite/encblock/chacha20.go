@@ -0,0 +1,53 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package encblock
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ricochhet/readwrite/readwrite"
+	"golang.org/x/crypto/chacha20"
+)
+
+var errChaCha20KeySize = errors.New("chacha20: key must be 32 bytes")
+
+// ChaCha20Cipher decrypts an EncBlock with the IETF ChaCha20 stream
+// cipher (256-bit key, 96-bit nonce). The nonce is derived from the
+// block's VA and FileOffset, zero-padded out to chacha20.NonceSize.
+type ChaCha20Cipher struct{}
+
+func (ChaCha20Cipher) Decrypt(dst, src []byte, block readwrite.EncBlock, key []byte) error {
+	if len(key) != chacha20.KeySize {
+		return errChaCha20KeySize
+	}
+
+	nonce := make([]byte, chacha20.NonceSize)
+	binary.LittleEndian.PutUint32(nonce[0:4], block.VA)
+	binary.LittleEndian.PutUint32(nonce[4:8], block.FileOffset)
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		return err
+	}
+
+	cipher.XORKeyStream(dst, src)
+
+	return nil
+}
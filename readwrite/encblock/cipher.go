@@ -0,0 +1,29 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package encblock decrypts and verifies the .ooa EncBlock payloads
+// described by readwrite.Section/readwrite.EncBlock.
+package encblock
+
+import "github.com/ricochhet/readwrite/readwrite"
+
+// BlockCipher decrypts a single EncBlock's ciphertext using the key the
+// caller resolved for it.
+type BlockCipher interface {
+	Decrypt(dst, src []byte, block readwrite.EncBlock, key []byte) error
+}
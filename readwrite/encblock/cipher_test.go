@@ -0,0 +1,135 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package encblock
+
+import (
+	"bytes"
+	"crypto/aes"
+	"debug/pe"
+	"hash/crc32"
+	"testing"
+
+	"github.com/ricochhet/readwrite/readwrite"
+	"golang.org/x/crypto/chacha20"
+)
+
+func TestChaCha20CipherRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, chacha20.KeySize)
+	plain := []byte("chacha20 plaintext block")
+	block := readwrite.EncBlock{VA: 0x1000, FileOffset: 0x2000}
+
+	cipher := ChaCha20Cipher{}
+
+	ciphertext := make([]byte, len(plain))
+	if err := cipher.Decrypt(ciphertext, plain, block, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(ciphertext, plain) {
+		t.Fatal("ciphertext equals plaintext, keystream was not applied")
+	}
+
+	decrypted := make([]byte, len(ciphertext))
+	if err := cipher.Decrypt(decrypted, ciphertext, block, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, plain) {
+		t.Fatalf("got %q, want %q", decrypted, plain)
+	}
+}
+
+func TestChaCha20CipherBadKeySize(t *testing.T) {
+	cipher := ChaCha20Cipher{}
+	dst := make([]byte, 4)
+
+	err := cipher.Decrypt(dst, []byte("abcd"), readwrite.EncBlock{}, []byte("short"))
+	if err != errChaCha20KeySize {
+		t.Fatalf("got err %v, want %v", err, errChaCha20KeySize)
+	}
+}
+
+func TestAESCTRCipherRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x24}, aes.BlockSize*2) // AES-256
+	plain := []byte("aes-ctr plaintext block")
+	block := readwrite.EncBlock{VA: 0x3000, FileOffset: 0x4000}
+
+	cipher := AESCTRCipher{}
+
+	ciphertext := make([]byte, len(plain))
+	if err := cipher.Decrypt(ciphertext, plain, block, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(ciphertext, plain) {
+		t.Fatal("ciphertext equals plaintext, keystream was not applied")
+	}
+
+	decrypted := make([]byte, len(ciphertext))
+	if err := cipher.Decrypt(decrypted, ciphertext, block, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, plain) {
+		t.Fatalf("got %q, want %q", decrypted, plain)
+	}
+}
+
+func TestReadAndDecryptSectionWithRegisteredCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, chacha20.KeySize)
+	plain := []byte("registered cipher plaintext")
+
+	algo := AlgoID{Unk: 7, Unk2: 7}
+	Register(algo, ChaCha20Cipher{})
+
+	block := readwrite.EncBlock{VA: 0, FileOffset: 0, Unk: algo.Unk, Unk2: algo.Unk2}
+
+	ciphertext := make([]byte, len(plain))
+	if err := (ChaCha20Cipher{}).Decrypt(ciphertext, plain, block, key); err != nil {
+		t.Fatal(err)
+	}
+
+	block.RawSize = uint32(len(ciphertext))
+	block.VirtualSize = uint32(len(ciphertext))
+	block.CRC = crc32.ChecksumIEEE(ciphertext)
+	block.CRC2 = crc32.ChecksumIEEE(plain)
+
+	sectionBytes := make([]byte, 0x1000)
+	copy(sectionBytes, ciphertext)
+
+	data := &readwrite.Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	sec := readwrite.Section{EncBlocks: []readwrite.EncBlock{block}}
+
+	got, err := ReadAndDecryptSection(data, sec, func(readwrite.EncBlock) []byte { return key })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("got %q, want %q", got, plain)
+	}
+}
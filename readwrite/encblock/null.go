@@ -16,25 +16,16 @@
  * along with this program.  If not, see <https://www.gnu.org/licenses/>.
  */
 
-package readwrite_test
+package encblock
 
-import (
-	"bytes"
-	"errors"
-	"testing"
+import "github.com/ricochhet/readwrite/readwrite"
 
-	"github.com/ricochhet/readwrite"
-)
+// NullCipher is a passthrough BlockCipher for EncBlocks that are already
+// plaintext.
+type NullCipher struct{}
 
-var errUnexpectedBytes = errors.New("unexpected bytes")
+func (NullCipher) Decrypt(dst, src []byte, _ readwrite.EncBlock, _ []byte) error {
+	copy(dst, src)
 
-func TestUtf8ToUtf16(t *testing.T) {
-	t.Parallel()
-
-	b := readwrite.Utf8ToUtf16("aaabbbccc")
-	o := []byte{97, 0, 97, 0, 97, 0, 98, 0, 98, 0, 98, 0, 99, 0, 99, 0, 99, 0}
-
-	if !bytes.Equal(b, o) {
-		t.Fatal(errUnexpectedBytes)
-	}
+	return nil
 }
@@ -0,0 +1,44 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package encblock
+
+// AlgoID identifies the cipher an EncBlock was encrypted with. In
+// practice the Unk/Unk2 fields of EncBlock tag the algorithm and its
+// variant.
+type AlgoID struct {
+	Unk  uint32
+	Unk2 uint32
+}
+
+//nolint:gochecknoglobals // registry is intentionally package-level state
+var registry = map[AlgoID]BlockCipher{
+	{Unk: 0, Unk2: 0}: NullCipher{},
+}
+
+// Register adds (or replaces) the BlockCipher used for id.
+func Register(id AlgoID, cipher BlockCipher) {
+	registry[id] = cipher
+}
+
+// Lookup returns the BlockCipher registered for id, if any.
+func Lookup(id AlgoID) (BlockCipher, bool) {
+	cipher, ok := registry[id]
+
+	return cipher, ok
+}
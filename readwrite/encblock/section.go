@@ -0,0 +1,76 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package encblock
+
+import (
+	"errors"
+	"hash/crc32"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+var (
+	errCipherTextCRCMismatch = errors.New("encblock: ciphertext CRC mismatch")
+	errPlainTextCRCMismatch  = errors.New("encblock: plaintext CRC mismatch")
+	errCipherNotRegistered   = errors.New("encblock: no cipher registered for block")
+)
+
+// ReadAndDecryptSection reads, verifies, and decrypts every EncBlock in
+// sec, returning the concatenated plaintext. keyring resolves the
+// decryption key for a given block (callers typically key off
+// block.VA or block.FileOffset).
+func ReadAndDecryptSection(file *readwrite.Data, sec readwrite.Section, keyring func(readwrite.EncBlock) []byte) ([]byte, error) {
+	var plaintext []byte
+
+	for _, block := range sec.EncBlocks {
+		ciphertext, err := readwrite.ReadSectionBytes(file, block.VA, block.RawSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if crc32.ChecksumIEEE(ciphertext) != block.CRC {
+			return nil, errCipherTextCRCMismatch
+		}
+
+		cipher, ok := Lookup(AlgoID{Unk: block.Unk, Unk2: block.Unk2})
+		if !ok {
+			return nil, errCipherNotRegistered
+		}
+
+		decrypted := make([]byte, block.RawSize)
+		if err := cipher.Decrypt(decrypted, ciphertext, block, keyring(block)); err != nil {
+			return nil, err
+		}
+
+		if crc32.ChecksumIEEE(decrypted) != block.CRC2 {
+			return nil, errPlainTextCRCMismatch
+		}
+
+		switch {
+		case block.VirtualSize > block.RawSize:
+			decrypted = readwrite.PadBytes(decrypted, int(block.VirtualSize))
+		case block.VirtualSize < block.RawSize:
+			decrypted = decrypted[:block.VirtualSize]
+		}
+
+		plaintext = append(plaintext, decrypted...)
+	}
+
+	return plaintext, nil
+}
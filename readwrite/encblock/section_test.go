@@ -0,0 +1,96 @@
+package encblock
+
+import (
+	"bytes"
+	"debug/pe"
+	"hash/crc32"
+	"testing"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+func TestReadAndDecryptSectionRoundTrip(t *testing.T) {
+	plain := []byte("hello, encblock")
+
+	sectionBytes := make([]byte, 0x1000)
+	copy(sectionBytes, plain)
+
+	data := &readwrite.Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	block := readwrite.EncBlock{
+		VA:          0,
+		RawSize:     uint32(len(plain)),
+		VirtualSize: uint32(len(plain)) + 4, // VirtualSize != RawSize pads on decrypt
+		CRC:         crc32.ChecksumIEEE(plain),
+		CRC2:        crc32.ChecksumIEEE(plain),
+	}
+
+	sec := readwrite.Section{EncBlocks: []readwrite.EncBlock{block}}
+
+	got, err := ReadAndDecryptSection(data, sec, func(readwrite.EncBlock) []byte { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := readwrite.PadBytes(append([]byte(nil), plain...), int(block.VirtualSize))
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadAndDecryptSectionPlainTextCRCMismatch(t *testing.T) {
+	plain := []byte("hello, encblock")
+
+	sectionBytes := make([]byte, 0x1000)
+	copy(sectionBytes, plain)
+
+	data := &readwrite.Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	block := readwrite.EncBlock{
+		VA:      0,
+		RawSize: uint32(len(plain)),
+		CRC:     crc32.ChecksumIEEE(plain),
+		CRC2:    crc32.ChecksumIEEE(plain) ^ 1,
+	}
+
+	sec := readwrite.Section{EncBlocks: []readwrite.EncBlock{block}}
+
+	_, err := ReadAndDecryptSection(data, sec, func(readwrite.EncBlock) []byte { return nil })
+	if err != errPlainTextCRCMismatch {
+		t.Fatalf("got err %v, want %v", err, errPlainTextCRCMismatch)
+	}
+}
+
+func TestReadAndDecryptSectionOutOfRangeBlock(t *testing.T) {
+	sectionBytes := make([]byte, 0x10)
+
+	data := &readwrite.Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	block := readwrite.EncBlock{VA: 0, RawSize: 0x1000}
+	sec := readwrite.Section{EncBlocks: []readwrite.EncBlock{block}}
+
+	if _, err := ReadAndDecryptSection(data, sec, func(readwrite.EncBlock) []byte { return nil }); err == nil {
+		t.Fatal("want error for a block whose RawSize extends past the section, got nil")
+	}
+}
@@ -0,0 +1,224 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package readwrite
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	errBadFatMagic            = errors.New("fatfile: bad magic")
+	errFatArchIndexOutOfRange = errors.New("fatfile: arch index out of range")
+	errTooManyFatArches       = errors.New("fatfile: arch count exceeds maximum allowed")
+)
+
+// FatMagic identifies a FatFile bundle.
+var FatMagic = [4]byte{'R', 'W', 'F', 'T'} //nolint:gochecknoglobals // wontfix
+
+// FatArchCountLen is the size of the big-endian arch count that follows
+// FatMagic.
+const FatArchCountLen = 4
+
+// FatArchEntrySize is the on-disk size of a FatArch record: Tag(4) +
+// Offset(8) + Size(8).
+const FatArchEntrySize = 4 + 8 + 8
+
+// maxFatArches bounds the number of FatArch records NewFatFile will
+// allocate for before reading a single one. The header's declared
+// count is attacker-controlled, so it is clamped well above anything
+// a real multi-arch bundle needs before it is trusted as an
+// allocation size.
+const maxFatArches = 1 << 16
+
+// FatArch locates one embedded PE image within a FatFile bundle.
+type FatArch struct {
+	Tag    uint32
+	Offset uint64
+	Size   uint64
+}
+
+// FatFile mirrors debug/macho.FatFile for multi-architecture PE
+// bundles: a dispatch header (FatMagic + big-endian arch count)
+// followed by an array of FatArch records, each pointing at a nested
+// PE image.
+type FatFile struct {
+	Arches []FatArch
+
+	r      io.ReaderAt
+	closer io.Closer
+}
+
+// OpenFat opens the file at path and parses it as a FatFile.
+func OpenFat(path string) (*FatFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fatFile, err := NewFatFile(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	fatFile.closer = file
+
+	return fatFile, nil
+}
+
+// NewFatFile parses a FatFile bundle out of r.
+func NewFatFile(r io.ReaderAt) (*FatFile, error) {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, err
+	}
+
+	if magic != FatMagic {
+		return nil, errBadFatMagic
+	}
+
+	var countBytes [FatArchCountLen]byte
+	if _, err := r.ReadAt(countBytes[:], int64(len(magic))); err != nil {
+		return nil, err
+	}
+
+	count := binary.BigEndian.Uint32(countBytes[:])
+	if count > maxFatArches {
+		return nil, errTooManyFatArches
+	}
+
+	arches := make([]FatArch, count)
+	offset := int64(len(magic) + FatArchCountLen)
+
+	for i := range arches {
+		entry := make([]byte, FatArchEntrySize)
+		if _, err := r.ReadAt(entry, offset); err != nil {
+			return nil, err
+		}
+
+		arches[i] = FatArch{
+			Tag:    binary.BigEndian.Uint32(entry[0:4]),
+			Offset: binary.BigEndian.Uint64(entry[4:12]),
+			Size:   binary.BigEndian.Uint64(entry[12:20]),
+		}
+		offset += FatArchEntrySize
+	}
+
+	return &FatFile{Arches: arches, r: r}, nil
+}
+
+// Arch lazily parses the i-th embedded PE image as a *Data, reusing the
+// pe.NewFile + ReadAll path Open uses for a plain PE file.
+func (ff *FatFile) Arch(i int) (*Data, error) {
+	if i < 0 || i >= len(ff.Arches) {
+		return nil, errFatArchIndexOutOfRange
+	}
+
+	arch := ff.Arches[i]
+
+	pefile, err := pe.NewFile(io.NewSectionReader(ff.r, int64(arch.Offset), int64(arch.Size)))
+	if err != nil {
+		return nil, err
+	}
+
+	allBytes, err := io.ReadAll(io.NewSectionReader(ff.r, int64(arch.Offset), int64(arch.Size)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Data{Bytes: allBytes, PE: *pefile}, nil
+}
+
+// Close closes the underlying file, if FatFile was created via OpenFat.
+func (ff *FatFile) Close() error {
+	if ff.closer == nil {
+		return nil
+	}
+
+	return ff.closer.Close()
+}
+
+type fatEntry struct {
+	tag  uint32
+	data *Data
+}
+
+// FatWriter packs multiple *Data inputs into a FatFile bundle,
+// computing and rewriting each FatArch's Offset as it lays out the
+// bundle.
+type FatWriter struct {
+	w       io.Writer
+	entries []fatEntry
+}
+
+func NewFatWriter(w io.Writer) *FatWriter {
+	return &FatWriter{w: w}
+}
+
+// Add queues data to be written under tag; the final Offset is
+// resolved by Close.
+func (fw *FatWriter) Add(tag uint32, data *Data) {
+	fw.entries = append(fw.entries, fatEntry{tag: tag, data: data})
+}
+
+// Close writes the dispatch header, the FatArch records with their
+// resolved offsets, and then every entry's bytes, in that order.
+func (fw *FatWriter) Close() error {
+	if _, err := fw.w.Write(FatMagic[:]); err != nil {
+		return err
+	}
+
+	countBytes := make([]byte, FatArchCountLen)
+	binary.BigEndian.PutUint32(countBytes, uint32(len(fw.entries)))
+
+	if _, err := fw.w.Write(countBytes); err != nil {
+		return err
+	}
+
+	offset := int64(len(FatMagic) + FatArchCountLen + len(fw.entries)*FatArchEntrySize)
+	arches := make([]FatArch, len(fw.entries))
+
+	for i, entry := range fw.entries {
+		arches[i] = FatArch{Tag: entry.tag, Offset: uint64(offset), Size: uint64(len(entry.data.Bytes))}
+		offset += int64(len(entry.data.Bytes))
+	}
+
+	for _, arch := range arches {
+		entry := make([]byte, FatArchEntrySize)
+		binary.BigEndian.PutUint32(entry[0:4], arch.Tag)
+		binary.BigEndian.PutUint64(entry[4:12], arch.Offset)
+		binary.BigEndian.PutUint64(entry[12:20], arch.Size)
+
+		if _, err := fw.w.Write(entry); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range fw.entries {
+		if _, err := fw.w.Write(entry.data.Bytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package readwrite
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+// buildMinimalPE returns the smallest byte sequence debug/pe.NewFile
+// will accept: a DOS header (just "MZ" plus the e_lfanew pointer to the
+// PE signature), the "PE\0\0" signature, and a zero-section, zero
+// optional-header COFF file header.
+func buildMinimalPE(t *testing.T) []byte {
+	t.Helper()
+
+	const dosHeaderSize = 96
+
+	dosHeader := make([]byte, dosHeaderSize)
+	dosHeader[0], dosHeader[1] = 'M', 'Z'
+	binary.LittleEndian.PutUint32(dosHeader[0x3c:], dosHeaderSize)
+
+	fileHeader := pe.FileHeader{
+		Machine:          pe.IMAGE_FILE_MACHINE_I386,
+		NumberOfSections: 0,
+	}
+
+	var buf bytes.Buffer
+	buf.Write(dosHeader)
+	buf.Write([]byte{'P', 'E', 0, 0})
+
+	if err := binary.Write(&buf, binary.LittleEndian, fileHeader); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFatWriterFatFileRoundTrip(t *testing.T) {
+	first := &Data{Bytes: []byte("first image bytes")}
+	second := &Data{Bytes: []byte("second image bytes, a bit longer")}
+
+	var buf bytes.Buffer
+
+	fw := NewFatWriter(&buf)
+	fw.Add(1, first)
+	fw.Add(2, second)
+
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+
+	ff, err := NewFatFile(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ff.Arches) != 2 {
+		t.Fatalf("got %d arches, want 2", len(ff.Arches))
+	}
+
+	if ff.Arches[0].Tag != 1 || ff.Arches[1].Tag != 2 {
+		t.Fatalf("unexpected tags: %+v", ff.Arches)
+	}
+
+	got := make([]byte, ff.Arches[0].Size)
+	if _, err := r.ReadAt(got, int64(ff.Arches[0].Offset)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, first.Bytes) {
+		t.Fatalf("first image = %q, want %q", got, first.Bytes)
+	}
+
+	got = make([]byte, ff.Arches[1].Size)
+	if _, err := r.ReadAt(got, int64(ff.Arches[1].Offset)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, second.Bytes) {
+		t.Fatalf("second image = %q, want %q", got, second.Bytes)
+	}
+}
+
+func TestFatFileArch(t *testing.T) {
+	peBytes := buildMinimalPE(t)
+
+	var buf bytes.Buffer
+
+	fw := NewFatWriter(&buf)
+	fw.Add(1, &Data{Bytes: peBytes})
+
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ff, err := NewFatFile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ff.Arch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data.Bytes, peBytes) {
+		t.Fatalf("arch bytes = %q, want %q", data.Bytes, peBytes)
+	}
+
+	if data.PE.Machine != pe.IMAGE_FILE_MACHINE_I386 {
+		t.Fatalf("arch Machine = %#x, want %#x", data.PE.Machine, pe.IMAGE_FILE_MACHINE_I386)
+	}
+
+	if _, err := ff.Arch(1); err != errFatArchIndexOutOfRange {
+		t.Fatalf("got err %v, want %v", err, errFatArchIndexOutOfRange)
+	}
+}
+
+func TestNewFatFileBadMagic(t *testing.T) {
+	_, err := NewFatFile(bytes.NewReader([]byte("not a fat file at all")))
+	if err != errBadFatMagic {
+		t.Fatalf("got err %v, want %v", err, errBadFatMagic)
+	}
+}
+
+func TestNewFatFileRejectsOversizedArchCount(t *testing.T) {
+	header := make([]byte, 8)
+	copy(header[:4], FatMagic[:])
+	binary.BigEndian.PutUint32(header[4:8], 200_000_000)
+
+	_, err := NewFatFile(bytes.NewReader(header))
+	if err != errTooManyFatArches {
+		t.Fatalf("got err %v, want %v", err, errTooManyFatArches)
+	}
+}
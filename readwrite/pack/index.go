@@ -0,0 +1,195 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package pack
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+// indexEntrySize is the on-disk size of a single central directory
+// record: FileName, both FNV-1a hashes, the absolute Offset, UncompSize,
+// Flags, Compression and StoredSize.
+const indexEntrySize = FileNameSize + 4 + 4 + 8 + 8 + 4 + 4 + 8
+
+// maxIndexEntries bounds the number of entries readIndexBody will
+// allocate for before it has read a single record. The footer's
+// declared count is attacker-controlled, so it is clamped well above
+// anything a real archive needs before it is trusted as an allocation
+// size.
+const maxIndexEntries = 1 << 20
+
+// Index is the loaded central directory, giving O(1) lookup by file
+// name or by either FNV-1a hash.
+type Index struct {
+	Entries []readwrite.FileEntry
+
+	byName  map[string]*readwrite.FileEntry
+	byLower map[uint32]*readwrite.FileEntry
+	byUpper map[uint32]*readwrite.FileEntry
+}
+
+func writeIndex(w io.Writer, entries []readwrite.FileEntry) error {
+	footerHeader := make([]byte, 8)
+	copy(footerHeader[:4], magicFooter[:])
+	binary.LittleEndian.PutUint32(footerHeader[4:8], uint32(len(entries)))
+
+	if _, err := w.Write(footerHeader); err != nil {
+		return err
+	}
+
+	for i := range entries {
+		buf, err := encodeIndexEntry(&entries[i])
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadIndex loads the central directory footer from r, which must be
+// positioned at the start of the footer (magic + entry count + records).
+func ReadIndex(r io.Reader) (*Index, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+
+	if magic != magicFooter {
+		return nil, errBadMagic
+	}
+
+	return readIndexBody(r)
+}
+
+// readIndexBody parses the entry count and records that follow an
+// already-consumed and verified footer magic.
+func readIndexBody(r io.Reader) (*Index, error) {
+	countBytes := make([]byte, 4)
+	if _, err := io.ReadFull(r, countBytes); err != nil {
+		return nil, err
+	}
+
+	count := binary.LittleEndian.Uint32(countBytes)
+	if count > maxIndexEntries {
+		return nil, errTooManyIndexEntries
+	}
+
+	entries := make([]readwrite.FileEntry, count)
+
+	buf := make([]byte, indexEntrySize)
+	for i := range entries {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		entry, err := decodeIndexEntry(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[i] = *entry
+	}
+
+	return newIndex(entries), nil
+}
+
+func newIndex(entries []readwrite.FileEntry) *Index {
+	idx := &Index{
+		Entries: entries,
+		byName:  make(map[string]*readwrite.FileEntry, len(entries)),
+		byLower: make(map[uint32]*readwrite.FileEntry, len(entries)),
+		byUpper: make(map[uint32]*readwrite.FileEntry, len(entries)),
+	}
+
+	for i := range idx.Entries {
+		entry := &idx.Entries[i]
+		idx.byName[entry.FileName] = entry
+		idx.byLower[entry.FileNameLower] = entry
+		idx.byUpper[entry.FileNameUpper] = entry
+	}
+
+	return idx
+}
+
+// FindByFileName returns the entry for fileName, if present.
+func (idx *Index) FindByFileName(fileName string) (*readwrite.FileEntry, bool) {
+	entry, ok := idx.byName[fileName]
+
+	return entry, ok
+}
+
+// FindByHash returns the entry whose FileNameLower or FileNameUpper
+// matches hash, if present.
+func (idx *Index) FindByHash(hash uint32) (*readwrite.FileEntry, bool) {
+	if entry, ok := idx.byLower[hash]; ok {
+		return entry, true
+	}
+
+	entry, ok := idx.byUpper[hash]
+
+	return entry, ok
+}
+
+func encodeIndexEntry(entry *readwrite.FileEntry) ([]byte, error) {
+	if len(entry.FileName)+1 > FileNameSize {
+		return nil, errFileNameTooLong
+	}
+
+	buf := make([]byte, indexEntrySize)
+	copy(buf, entry.FileName)
+
+	rest := buf[FileNameSize:]
+	binary.LittleEndian.PutUint32(rest[0:4], entry.FileNameLower)
+	binary.LittleEndian.PutUint32(rest[4:8], entry.FileNameUpper)
+	binary.LittleEndian.PutUint64(rest[8:16], entry.Offset)
+	binary.LittleEndian.PutUint64(rest[16:24], entry.UncompSize)
+	binary.LittleEndian.PutUint32(rest[24:28], entry.Flags)
+	binary.LittleEndian.PutUint32(rest[28:32], entry.Compression)
+	binary.LittleEndian.PutUint64(rest[32:40], entry.StoredSize)
+
+	return buf, nil
+}
+
+func decodeIndexEntry(buf []byte) (*readwrite.FileEntry, error) {
+	if len(buf) != indexEntrySize {
+		return nil, errShortHeader
+	}
+
+	name := decodeFileName(buf[:FileNameSize])
+	rest := buf[FileNameSize:]
+
+	return &readwrite.FileEntry{
+		FileName:      name,
+		FileNameLower: binary.LittleEndian.Uint32(rest[0:4]),
+		FileNameUpper: binary.LittleEndian.Uint32(rest[4:8]),
+		Offset:        binary.LittleEndian.Uint64(rest[8:16]),
+		UncompSize:    binary.LittleEndian.Uint64(rest[16:24]),
+		Flags:         binary.LittleEndian.Uint32(rest[24:28]),
+		Compression:   binary.LittleEndian.Uint32(rest[28:32]),
+		StoredSize:    binary.LittleEndian.Uint64(rest[32:40]),
+	}, nil
+}
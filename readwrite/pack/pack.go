@@ -0,0 +1,124 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package pack implements a tar-style, 512-byte block-aligned streaming
+// container for readwrite.FileEntry payloads, with a central directory
+// footer for O(1) random-access lookup once the index is loaded.
+package pack
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"strings"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+const (
+	// BlockSize is the alignment every header and payload is padded to.
+	BlockSize = 512
+	// FileNameSize is the maximum length of a header's FileName field,
+	// including its NUL terminator.
+	FileNameSize = 256
+
+	// Compression tags for FileEntry.Compression.
+	CompressionNone = 0
+	CompressionZlib = 1
+	CompressionZstd = 2
+)
+
+var (
+	errFileNameTooLong     = errors.New("pack: file name too long for header")
+	errBadMagic            = errors.New("pack: bad footer magic")
+	errShortHeader         = errors.New("pack: short header")
+	errTooManyIndexEntries = errors.New("pack: index entry count exceeds maximum allowed")
+)
+
+// magicFooter identifies the central directory footer.
+var magicFooter = [4]byte{'R', 'W', 'P', 'K'} //nolint:gochecknoglobals // wontfix
+
+func hashLower(fileName string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(fileName)))
+
+	return h.Sum32()
+}
+
+func hashUpper(fileName string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(fileName)))
+
+	return h.Sum32()
+}
+
+func padding(size int64) int64 {
+	remainder := size % BlockSize
+	if remainder == 0 {
+		return 0
+	}
+
+	return BlockSize - remainder
+}
+
+func encodeHeader(entry *readwrite.FileEntry) ([]byte, error) {
+	if len(entry.FileName)+1 > FileNameSize {
+		return nil, errFileNameTooLong
+	}
+
+	header := make([]byte, BlockSize)
+	copy(header, entry.FileName)
+
+	rest := header[FileNameSize:]
+	binary.LittleEndian.PutUint32(rest[0:4], entry.FileNameLower)
+	binary.LittleEndian.PutUint32(rest[4:8], entry.FileNameUpper)
+	binary.LittleEndian.PutUint64(rest[8:16], entry.UncompSize)
+	binary.LittleEndian.PutUint32(rest[16:20], entry.Flags)
+	binary.LittleEndian.PutUint32(rest[20:24], entry.Compression)
+	binary.LittleEndian.PutUint64(rest[24:32], entry.StoredSize)
+
+	return header, nil
+}
+
+func decodeFileName(raw []byte) string {
+	nameEnd := strings.IndexByte(string(raw), 0)
+	if nameEnd == -1 {
+		nameEnd = len(raw)
+	}
+
+	return string(raw[:nameEnd])
+}
+
+func decodeHeader(header []byte) (*readwrite.FileEntry, error) {
+	if len(header) != BlockSize {
+		return nil, errShortHeader
+	}
+
+	name := decodeFileName(header[:FileNameSize])
+	rest := header[FileNameSize:]
+
+	return &readwrite.FileEntry{
+		FileName:      name,
+		FileNameLower: binary.LittleEndian.Uint32(rest[0:4]),
+		FileNameUpper: binary.LittleEndian.Uint32(rest[4:8]),
+		UncompSize:    binary.LittleEndian.Uint64(rest[8:16]),
+		Flags:         binary.LittleEndian.Uint32(rest[16:20]),
+		Compression:   binary.LittleEndian.Uint32(rest[20:24]),
+		StoredSize:    binary.LittleEndian.Uint64(rest[24:32]),
+	}, nil
+}
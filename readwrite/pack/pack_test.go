@@ -0,0 +1,119 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	plainPayload := []byte("uncompressed payload")
+
+	var compressedBuf bytes.Buffer
+
+	zw := zlib.NewWriter(&compressedBuf)
+	if _, err := zw.Write([]byte("this is the payload that gets compressed for the test")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	compressedPayload := compressedBuf.Bytes()
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+
+	if err := w.WriteHeader(&readwrite.FileEntry{FileName: "plain.txt", UncompSize: uint64(len(plainPayload))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(plainPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteHeader(&readwrite.FileEntry{
+		FileName:    "compressed.bin",
+		UncompSize:  55,
+		Compression: CompressionZlib,
+		StoredSize:  uint64(len(compressedPayload)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(compressedPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(&buf)
+
+	entry, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, entry.StoredSize)
+	if _, err := r.Read(got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plainPayload) {
+		t.Fatalf("plain entry = %q, want %q", got, plainPayload)
+	}
+
+	entry, err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.StoredSize != uint64(len(compressedPayload)) {
+		t.Fatalf("StoredSize = %d, want %d", entry.StoredSize, len(compressedPayload))
+	}
+
+	got = make([]byte, entry.StoredSize)
+	if _, err := r.Read(got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, compressedPayload) {
+		t.Fatalf("compressed entry bytes did not round-trip")
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("got err %v, want io.EOF", err)
+	}
+
+	index := r.Index()
+	if index == nil {
+		t.Fatal("Index() returned nil after Next reached the footer")
+	}
+
+	entry, ok := index.FindByFileName("compressed.bin")
+	if !ok {
+		t.Fatal("FindByFileName(\"compressed.bin\") not found")
+	}
+
+	if entry.StoredSize != uint64(len(compressedPayload)) {
+		t.Fatalf("indexed StoredSize = %d, want %d", entry.StoredSize, len(compressedPayload))
+	}
+}
+
+func TestReadIndexRejectsOversizedCount(t *testing.T) {
+	footer := make([]byte, 8)
+	copy(footer[:4], magicFooter[:])
+	binary.LittleEndian.PutUint32(footer[4:8], 200_000_000)
+
+	if _, err := ReadIndex(bytes.NewReader(footer)); err != errTooManyIndexEntries {
+		t.Fatalf("got err %v, want %v", err, errTooManyIndexEntries)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package pack
+
+import (
+	"io"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+// Reader reads entries written by Writer, matching the ergonomics of
+// archive/tar: call Next to advance to the next entry, then read its
+// payload from the Reader itself.
+type Reader struct {
+	r         io.Reader
+	pos       int64
+	remaining int64 // unread payload bytes for the current entry
+	pad       int64 // unread padding bytes for the current entry
+	index     *Index
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Next advances to the next entry and returns its header, skipping any
+// unread payload and padding left over from the previous entry. It
+// returns io.EOF once the central directory footer is reached; the
+// footer itself is not block-aligned, so Next only peeks its magic
+// before deciding whether to decode a header or stop. Since r is a
+// plain io.Reader, the footer's magic can't be put back once read, so
+// Next parses the footer itself and makes it available from Index
+// rather than leaving it for a separate ReadIndex call on r.
+func (pr *Reader) Next() (*readwrite.FileEntry, error) {
+	if err := pr.skipCurrentEntry(); err != nil {
+		return nil, err
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(pr.r, magic[:]); err != nil {
+		return nil, err
+	}
+
+	pr.pos += 4
+
+	if magic == magicFooter {
+		index, err := readIndexBody(pr.r)
+		if err != nil {
+			return nil, err
+		}
+
+		pr.index = index
+
+		return nil, io.EOF
+	}
+
+	header := make([]byte, BlockSize)
+	copy(header, magic[:])
+
+	if _, err := io.ReadFull(pr.r, header[4:]); err != nil {
+		return nil, err
+	}
+
+	pr.pos += BlockSize - 4
+
+	entry, err := decodeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Offset = uint64(pr.pos)
+	pr.remaining = int64(entry.StoredSize)
+	pr.pad = padding(pr.remaining)
+
+	return entry, nil
+}
+
+// Index returns the central directory parsed by Next once it has
+// returned io.EOF. It is nil until then.
+func (pr *Reader) Index() *Index {
+	return pr.index
+}
+
+// Read reads from the payload of the entry returned by the most recent
+// Next call.
+func (pr *Reader) Read(p []byte) (int, error) {
+	if pr.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > pr.remaining {
+		p = p[:pr.remaining]
+	}
+
+	n, err := pr.r.Read(p)
+	pr.remaining -= int64(n)
+	pr.pos += int64(n)
+
+	return n, err
+}
+
+func (pr *Reader) skipCurrentEntry() error {
+	skip := pr.remaining + pr.pad
+	if skip == 0 {
+		return nil
+	}
+
+	n, err := io.CopyN(io.Discard, pr.r, skip)
+	pr.pos += n
+	pr.remaining = 0
+	pr.pad = 0
+
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package pack
+
+import (
+	"io"
+
+	"github.com/ricochhet/readwrite/readwrite"
+)
+
+// Writer streams FileEntry headers and their payloads, tar-style: a
+// 512-byte header, the payload padded out to the next 512-byte
+// boundary, then the next header. Close writes the central directory
+// footer.
+type Writer struct {
+	w       io.Writer
+	pos     int64
+	written int64 // bytes written for the current entry's payload
+	index   []readwrite.FileEntry
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteHeader finishes padding the previous entry (if any), computes
+// FileNameLower/FileNameUpper and Offset on entry, and writes the
+// header. Subsequent Write calls stream that entry's payload.
+func (pw *Writer) WriteHeader(entry *readwrite.FileEntry) error {
+	if err := pw.padCurrentEntry(); err != nil {
+		return err
+	}
+
+	entry.FileNameLower = hashLower(entry.FileName)
+	entry.FileNameUpper = hashUpper(entry.FileName)
+	entry.Offset = uint64(pw.pos) + BlockSize
+
+	// StoredSize is the physical byte count written to the stream for
+	// this entry; it only diverges from UncompSize when the caller
+	// applied compression, so default it for the common uncompressed
+	// case.
+	if entry.StoredSize == 0 {
+		entry.StoredSize = entry.UncompSize
+	}
+
+	header, err := encodeHeader(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pw.w.Write(header); err != nil {
+		return err
+	}
+
+	pw.pos += BlockSize
+	pw.written = 0
+	pw.index = append(pw.index, *entry)
+
+	return nil
+}
+
+// Write streams payload bytes for the entry started by the most recent
+// WriteHeader call.
+func (pw *Writer) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.pos += int64(n)
+	pw.written += int64(n)
+
+	return n, err
+}
+
+func (pw *Writer) padCurrentEntry() error {
+	pad := padding(pw.written)
+	if pad == 0 {
+		return nil
+	}
+
+	if _, err := pw.w.Write(make([]byte, pad)); err != nil {
+		return err
+	}
+
+	pw.pos += pad
+
+	return nil
+}
+
+// Close pads the final entry and writes the central directory footer
+// (magic + entry count + the FileEntry records, each with its absolute
+// Offset already resolved) so Index.Load can do O(1) lookups.
+func (pw *Writer) Close() error {
+	if err := pw.padCurrentEntry(); err != nil {
+		return err
+	}
+
+	return writeIndex(pw.w, pw.index)
+}
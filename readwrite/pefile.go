@@ -19,19 +19,29 @@
 package readwrite
 
 import (
+	"bytes"
+	"compress/zlib"
 	"debug/pe"
 	"encoding/binary"
 	"errors"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
 var (
-	errInvalidOffsetOrByteRange = errors.New("invalid offset or byte range")
-	errSectionHeaderIsSizeZero  = errors.New("section header size is 0")
-	errSectionIsNil             = errors.New("section is nil")
-	errNoBytes                  = errors.New("no bytes")
+	errInvalidOffsetOrByteRange     = errors.New("invalid offset or byte range")
+	errSectionHeaderIsSizeZero      = errors.New("section header size is 0")
+	errSectionIsNil                 = errors.New("section is nil")
+	errNoBytes                      = errors.New("no bytes")
+	errUnknownOptionalHeaderMagic   = errors.New("unknown optional header magic")
+	errStringTableOffsetTooSmall    = errors.New("string table offset is before the size of the string table")
+	errStringTableOffsetOutOfRange  = errors.New("string table offset is outside the range of the string table")
+	errStringTableMissingTerminator = errors.New("string table entry is missing its NUL terminator")
+	errSectionRangeOutOfBounds      = errors.New("section byte range is out of bounds")
+	errUncompressedSizeTooLarge     = errors.New("uncompressed size exceeds maximum allowed size")
 )
 
 // COFFHeader
@@ -44,6 +54,15 @@ const (
 	COFFHeaderSize    = 20
 )
 
+// OptionalHeaderMagic
+// https://github.com/golang/go/blob/master/src/debug/pe/pe.go
+// PE32 == OptionalHeader32, PE32Plus == OptionalHeader64.
+const (
+	OptionalHeaderMagicPE32      = 0x10b
+	OptionalHeaderMagicPE32Plus  = 0x20b
+	OptionalHeaderMagicOffsetLen = 2
+)
+
 // OptionalHeader64
 // https://github.com/golang/go/blob/master/src/debug/pe/pe.go
 // uint byte size of OptionalHeader64 without magic mumber(2 bytes) or data directory(128 bytes)
@@ -51,6 +70,13 @@ const (
 // (110).
 var OH64ByteSize = binary.Size(OptionalHeader64X110{}) //nolint:exhaustruct,gochecknoglobals // wontfix
 
+// OptionalHeader32
+// https://github.com/golang/go/blob/master/src/debug/pe/pe.go
+// uint byte size of OptionalHeader32 without magic number(2 bytes) or data directory(128 bytes)
+// OptionalHeader32 size is 224
+// (94).
+var OH32ByteSize = binary.Size(OptionalHeader32X94{}) //nolint:exhaustruct,gochecknoglobals // wontfix
+
 // DataDirectory
 // 16 entries * 8 bytes / entry.
 const (
@@ -155,6 +181,38 @@ type OptionalHeader64X110 struct {
 	NumberOfRvaAndSizes         uint32
 }
 
+type OptionalHeader32X94 struct {
+	MajorLinkerVersion          uint8
+	MinorLinkerVersion          uint8
+	SizeOfCode                  uint32
+	SizeOfInitializedData       uint32
+	SizeOfUninitializedData     uint32
+	AddressOfEntryPoint         uint32
+	BaseOfCode                  uint32
+	BaseOfData                  uint32
+	ImageBase                   uint32
+	SectionAlignment            uint32
+	FileAlignment               uint32
+	MajorOperatingSystemVersion uint16
+	MinorOperatingSystemVersion uint16
+	MajorImageVersion           uint16
+	MinorImageVersion           uint16
+	MajorSubsystemVersion       uint16
+	MinorSubsystemVersion       uint16
+	Win32VersionValue           uint32
+	SizeOfImage                 uint32
+	SizeOfHeaders               uint32
+	CheckSum                    uint32
+	Subsystem                   uint16
+	DllCharacteristics          uint16
+	SizeOfStackReserve          uint32
+	SizeOfStackCommit           uint32
+	SizeOfHeapReserve           uint32
+	SizeOfHeapCommit            uint32
+	LoaderFlags                 uint32
+	NumberOfRvaAndSizes         uint32
+}
+
 type SectionHeader32X28 struct {
 	VirtualSize          uint32
 	VirtualAddress       uint32
@@ -210,13 +268,53 @@ func ReadCOFFHeaderOffset(bytes []byte) (int, error) {
 	return offset, nil
 }
 
+// ReadOptionalHeaderMagic reads the magic word at the start of the
+// OptionalHeader (0x10b == PE32, 0x20b == PE32+) to tell a 32-bit PE
+// apart from a 64-bit one.
+func ReadOptionalHeaderMagic(bytes []byte) (uint16, error) {
+	offset, err := ReadCOFFHeaderOffset(bytes)
+	if err != nil {
+		return 0, err
+	}
+
+	magicOffset := offset + COFFStartBytesLen + COFFHeaderSize
+	if magicOffset+OptionalHeaderMagicOffsetLen > len(bytes) {
+		return 0, errNoBytes
+	}
+
+	return binary.LittleEndian.Uint16(bytes[magicOffset : magicOffset+OptionalHeaderMagicOffsetLen]), nil
+}
+
+// ReadOHSize returns the OptionalHeader field size (OH32ByteSize or
+// OH64ByteSize) for the PE variant bytes was parsed from.
+func ReadOHSize(bytes []byte) (int, error) {
+	magic, err := ReadOptionalHeaderMagic(bytes)
+	if err != nil {
+		return -1, err
+	}
+
+	switch magic {
+	case OptionalHeaderMagicPE32:
+		return OH32ByteSize, nil
+	case OptionalHeaderMagicPE32Plus:
+		return OH64ByteSize, nil
+	default:
+		return -1, errUnknownOptionalHeaderMagic
+	}
+}
+
 func ReadDDBytes(bytes []byte) ([]byte, error) {
 	offset, err := ReadCOFFHeaderOffset(bytes)
 	if err != nil {
 		return nil, err
 	}
 
-	return bytes[offset+COFFStartBytesLen+COFFHeaderSize+OH64ByteSize : offset+COFFStartBytesLen+COFFHeaderSize+OH64ByteSize+DataDirSize], nil
+	ohSize, err := ReadOHSize(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes[offset+COFFStartBytesLen+COFFHeaderSize+ohSize : offset+COFFStartBytesLen+COFFHeaderSize+ohSize+DataDirSize], nil
 }
 
 func ReadDDEntryOffset(bytes []byte, entryVirtualAddress uint32, entrySize uint32) (int, error) {
@@ -240,7 +338,12 @@ func ReadDDEntryOffset(bytes []byte, entryVirtualAddress uint32, entrySize uint3
 		return -1, err
 	}
 
-	return offset + COFFStartBytesLen + COFFHeaderSize + OH64ByteSize + rva, nil
+	ohSize, err := ReadOHSize(bytes)
+	if err != nil {
+		return -1, err
+	}
+
+	return offset + COFFStartBytesLen + COFFHeaderSize + ohSize + rva, nil
 }
 
 func ReadSHSize(file pe.File) (int, error) {
@@ -260,7 +363,12 @@ func ReadSHBytes(bytes []byte, shSize int) ([]byte, error) {
 		return nil, err
 	}
 
-	return bytes[offset+COFFStartBytesLen+COFFHeaderSize+OH64ByteSize+DataDirSize : offset+COFFStartBytesLen+COFFHeaderSize+OH64ByteSize+DataDirSize+shSize], nil //nolint:lll // wontfix
+	ohSize, err := ReadOHSize(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes[offset+COFFStartBytesLen+COFFHeaderSize+ohSize+DataDirSize : offset+COFFStartBytesLen+COFFHeaderSize+ohSize+DataDirSize+shSize], nil //nolint:lll // wontfix
 }
 
 func ReadSHEntryOffset(bytes []byte, address int) (int, error) {
@@ -269,7 +377,12 @@ func ReadSHEntryOffset(bytes []byte, address int) (int, error) {
 		return -1, err
 	}
 
-	return offset + COFFStartBytesLen + COFFHeaderSize + OH64ByteSize + DataDirSize + address, nil
+	ohSize, err := ReadOHSize(bytes)
+	if err != nil {
+		return -1, err
+	}
+
+	return offset + COFFStartBytesLen + COFFHeaderSize + ohSize + DataDirSize + address, nil
 }
 
 func ReadSectionBytes(file *Data, sectionVirtualAddress uint32, sectionSize uint32) ([]byte, error) {
@@ -287,11 +400,87 @@ func ReadSectionBytes(file *Data, sectionVirtualAddress uint32, sectionSize uint
 	}
 
 	offset := sectionVirtualAddress - section.VirtualAddress + section.Offset
-	bytes := file.Bytes[offset : offset+sectionSize]
+	end := uint64(offset) + uint64(sectionSize)
+
+	if end > uint64(section.Offset)+uint64(section.Size) || end > uint64(len(file.Bytes)) {
+		return nil, errSectionRangeOutOfBounds
+	}
+
+	bytes := file.Bytes[offset:end]
 
 	return bytes, nil
 }
 
+// zlibSectionPrefix and zlibSectionHeaderLen describe the compressed
+// DWARF section format emitted by modern toolchains: the ASCII prefix
+// "ZLIB" followed by an 8-byte big-endian uncompressed size, then a
+// zlib stream. debug/pe handles this transparently in Section.Data;
+// ReadSectionBytes does not, so ReadSectionData adds it on top.
+var zlibSectionPrefix = []byte("ZLIB") //nolint:gochecknoglobals // wontfix
+
+const zlibSectionHeaderLen = 4 + 8
+
+// maxUncompressedSectionSize bounds the size ReadSectionData will
+// allocate for an inflated section. The "ZLIB" header's declared
+// uncompressed size is attacker-controlled, so it is clamped well
+// above anything a real debug section needs before it is trusted
+// as an allocation size.
+const maxUncompressedSectionSize = 512 * 1024 * 1024
+
+// ReadSectionData behaves like ReadSectionBytes, but transparently
+// inflates sections whose raw data begins with the "ZLIB" compression
+// prefix.
+func ReadSectionData(file *Data, sectionVirtualAddress uint32, sectionSize uint32) ([]byte, error) {
+	raw, err := ReadSectionBytes(file, sectionVirtualAddress, sectionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < zlibSectionHeaderLen || !bytes.Equal(raw[:4], zlibSectionPrefix) {
+		return raw, nil
+	}
+
+	uncompressedSize := binary.BigEndian.Uint64(raw[4:zlibSectionHeaderLen])
+	if uncompressedSize > maxUncompressedSectionSize {
+		return nil, errUncompressedSizeTooLarge
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw[zlibSectionHeaderLen:]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	out := make([]byte, uncompressedSize)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ResolveSectionName resolves a raw section header Name field,
+// following the "/NN" convention GCC/MinGW use for names too long for
+// the fixed 8-byte field: NN is a decimal offset into the COFF string
+// table.
+func ResolveSectionName(file *Data, name string) (string, error) {
+	if !strings.HasPrefix(name, "/") {
+		return name, nil
+	}
+
+	offset, err := strconv.ParseUint(strings.TrimRight(name[1:], "\x00"), 10, 32)
+	if err != nil {
+		return "", err
+	}
+
+	strTable, err := ReadStringTable(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strTable.String(uint32(offset))
+}
+
 func ReadImport(reader io.Reader) (Import, error) {
 	var importData Import
 	err := binary.Read(reader, binary.LittleEndian, &importData)
@@ -350,3 +539,150 @@ func MatchBytes(src []byte, dst []byte) bool {
 
 	return true
 }
+
+// COFFSymbolSize is the on-disk size of a COFFSymbol record.
+const COFFSymbolSize = 18
+
+// Symbol is the auxiliary-collapsed symbol shape returned by
+// RemoveAuxSymbols, mirroring debug/pe.Symbol.
+type Symbol struct {
+	Name          string
+	Value         uint32
+	SectionNumber int16
+	Type          uint16
+	StorageClass  uint8
+}
+
+// COFFSymbol mirrors the raw, on-disk COFF symbol table record read by
+// debug/pe.
+type COFFSymbol struct {
+	Name               [8]byte
+	Value              uint32
+	SectionNumber      int16
+	Type               uint16
+	StorageClass       uint8
+	NumberOfAuxSymbols uint8
+}
+
+// StringTable is the raw COFF string table trailing the symbol table.
+type StringTable []byte
+
+// ReadCOFFSymbols parses the raw symbol table pointed to by the COFF
+// header's PointerToSymbolTable/NumberOfSymbols.
+func ReadCOFFSymbols(file *Data) ([]COFFSymbol, error) {
+	fh := file.PE.FileHeader
+	if fh.PointerToSymbolTable == 0 || fh.NumberOfSymbols <= 0 {
+		return nil, nil
+	}
+
+	offset := int(fh.PointerToSymbolTable)
+	if offset+COFFSymbolSize*int(fh.NumberOfSymbols) > len(file.Bytes) {
+		return nil, errNoBytes
+	}
+
+	syms := make([]COFFSymbol, fh.NumberOfSymbols)
+	if err := binary.Read(bytes.NewReader(file.Bytes[offset:]), binary.LittleEndian, syms); err != nil {
+		return nil, err
+	}
+
+	return syms, nil
+}
+
+// ReadStringTable parses the string table that trails the symbol table:
+// a 4-byte little-endian length prefix (inclusive of itself) followed by
+// NUL-terminated entries.
+func ReadStringTable(file *Data) (StringTable, error) {
+	fh := file.PE.FileHeader
+	if fh.PointerToSymbolTable == 0 {
+		return nil, nil
+	}
+
+	offset := int(fh.PointerToSymbolTable) + COFFSymbolSize*int(fh.NumberOfSymbols)
+	if offset+4 > len(file.Bytes) {
+		return nil, errNoBytes
+	}
+
+	length := binary.LittleEndian.Uint32(file.Bytes[offset : offset+4])
+	if length <= 4 {
+		return nil, nil
+	}
+
+	start := offset + 4
+	end := start + int(length-4)
+
+	if end > len(file.Bytes) {
+		return nil, errNoBytes
+	}
+
+	return StringTable(file.Bytes[start:end]), nil
+}
+
+// String resolves an offset (as stored in a COFFSymbol long name) into
+// the string table.
+func (st StringTable) String(start uint32) (string, error) {
+	if start < 4 {
+		return "", errStringTableOffsetTooSmall
+	}
+
+	start -= 4
+	if int(start) >= len(st) {
+		return "", errStringTableOffsetOutOfRange
+	}
+
+	for end := start; end < uint32(len(st)); end++ {
+		if st[end] == 0 {
+			return string(st[start:end]), nil
+		}
+	}
+
+	return "", errStringTableMissingTerminator
+}
+
+func (sym *COFFSymbol) fullName(strTable StringTable) (string, error) {
+	name := sym.Name[:]
+	if name[0] == 0 && name[1] == 0 && name[2] == 0 && name[3] == 0 {
+		return strTable.String(binary.LittleEndian.Uint32(name[4:]))
+	}
+
+	length := bytes.IndexByte(name, 0)
+	if length == -1 {
+		length = len(name)
+	}
+
+	return string(name[:length]), nil
+}
+
+// RemoveAuxSymbols collapses raw COFF symbol records into Symbols,
+// skipping the auxiliary records that follow each primary symbol,
+// mirroring how debug/pe exposes a clean symbol list.
+func RemoveAuxSymbols(allSyms []COFFSymbol, strTable StringTable) ([]*Symbol, error) {
+	if len(allSyms) == 0 {
+		return nil, nil
+	}
+
+	syms := make([]*Symbol, 0, len(allSyms))
+
+	var aux uint8
+	for _, sym := range allSyms {
+		if aux > 0 {
+			aux--
+			continue
+		}
+
+		name, err := sym.fullName(strTable)
+		if err != nil {
+			return nil, err
+		}
+
+		aux = sym.NumberOfAuxSymbols
+		syms = append(syms, &Symbol{
+			Name:          name,
+			Value:         sym.Value,
+			SectionNumber: sym.SectionNumber,
+			Type:          sym.Type,
+			StorageClass:  sym.StorageClass,
+		})
+	}
+
+	return syms, nil
+}
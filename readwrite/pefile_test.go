@@ -0,0 +1,192 @@
+package readwrite
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+// buildStringTable encodes a COFF string table: a 4-byte little-endian
+// length prefix (inclusive of itself) followed by NUL-terminated entries.
+func buildStringTable(names ...string) []byte {
+	var body []byte
+	for _, name := range names {
+		body = append(body, append([]byte(name), 0)...)
+	}
+
+	table := make([]byte, 4+len(body))
+	binary.LittleEndian.PutUint32(table[:4], uint32(len(table)))
+	copy(table[4:], body)
+
+	return table
+}
+
+func TestReadCOFFSymbolsAndStringTable(t *testing.T) {
+	strTable := buildStringTable("a_very_long_symbol_name")
+
+	var short [8]byte
+	copy(short[:], "short")
+
+	var long [8]byte
+	binary.LittleEndian.PutUint32(long[4:], 4) // offset 4 == first entry
+
+	syms := []COFFSymbol{
+		{Name: short, StorageClass: 2},
+		{Name: long, StorageClass: 2},
+	}
+
+	buf := make([]byte, COFFSymbolSize*len(syms))
+	for i, sym := range syms {
+		off := i * COFFSymbolSize
+		copy(buf[off:off+8], sym.Name[:])
+		binary.LittleEndian.PutUint32(buf[off+8:off+12], sym.Value)
+		binary.LittleEndian.PutUint16(buf[off+12:off+14], uint16(sym.SectionNumber))
+		binary.LittleEndian.PutUint16(buf[off+14:off+16], sym.Type)
+		buf[off+16] = sym.StorageClass
+		buf[off+17] = sym.NumberOfAuxSymbols
+	}
+
+	const headerPad = 4
+
+	raw := append(make([]byte, headerPad), buf...)
+	raw = append(raw, strTable...)
+
+	data := &Data{
+		Bytes: raw,
+		PE: pe.File{
+			FileHeader: pe.FileHeader{
+				PointerToSymbolTable: headerPad,
+				NumberOfSymbols:      uint32(len(syms)),
+			},
+		},
+	}
+
+	rawSyms, err := ReadCOFFSymbols(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rawSyms) != len(syms) {
+		t.Fatalf("got %d symbols, want %d", len(rawSyms), len(syms))
+	}
+
+	gotTable, err := ReadStringTable(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := RemoveAuxSymbols(rawSyms, gotTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resolved) != 2 {
+		t.Fatalf("got %d resolved symbols, want 2", len(resolved))
+	}
+
+	if resolved[0].Name != "short" {
+		t.Fatalf("short name = %q, want %q", resolved[0].Name, "short")
+	}
+
+	if resolved[1].Name != "a_very_long_symbol_name" {
+		t.Fatalf("long name = %q, want %q", resolved[1].Name, "a_very_long_symbol_name")
+	}
+}
+
+func TestReadSectionDataInflatesZlibPrefix(t *testing.T) {
+	want := []byte("the uncompressed section contents")
+
+	var compressed bytes.Buffer
+
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(want); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := append([]byte("ZLIB"), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(raw[4:12], uint64(len(want)))
+	raw = append(raw, compressed.Bytes()...)
+
+	sectionBytes := make([]byte, 0x1000)
+	copy(sectionBytes, raw)
+
+	data := &Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	got, err := ReadSectionData(data, 0, uint32(len(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadSectionDataRejectsOversizedUncompressedSize(t *testing.T) {
+	raw := append([]byte("ZLIB"), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(raw[4:12], maxUncompressedSectionSize+1)
+	raw = append(raw, 0) // placeholder zlib stream byte; never reached
+
+	sectionBytes := make([]byte, 0x1000)
+	copy(sectionBytes, raw)
+
+	data := &Data{
+		Bytes: sectionBytes,
+		PE: pe.File{
+			Sections: []*pe.Section{
+				{SectionHeader: pe.SectionHeader{VirtualAddress: 0, Size: uint32(len(sectionBytes)), Offset: 0}},
+			},
+		},
+	}
+
+	if _, err := ReadSectionData(data, 0, uint32(len(raw))); err != errUncompressedSizeTooLarge {
+		t.Fatalf("got err %v, want %v", err, errUncompressedSizeTooLarge)
+	}
+}
+
+func TestResolveSectionNameLongName(t *testing.T) {
+	strTable := buildStringTable("a_very_long_section_name")
+
+	const headerPad = 4
+
+	data := &Data{
+		Bytes: append(make([]byte, headerPad), strTable...),
+		PE: pe.File{
+			FileHeader: pe.FileHeader{
+				PointerToSymbolTable: headerPad,
+				NumberOfSymbols:      0,
+			},
+		},
+	}
+
+	got, err := ResolveSectionName(data, "/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "a_very_long_section_name" {
+		t.Fatalf("got %q, want %q", got, "a_very_long_section_name")
+	}
+
+	got, err = ResolveSectionName(data, ".text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != ".text" {
+		t.Fatalf("got %q, want %q", got, ".text")
+	}
+}
@@ -25,7 +25,13 @@ import (
 )
 
 type Reader struct {
-	file *os.File
+	storage Storage
+	pos     int64
+}
+
+// NewReaderFromStorage wraps an arbitrary Storage backend in a Reader.
+func NewReaderFromStorage(storage Storage) *Reader {
+	return &Reader{storage: storage}
 }
 
 func NewReader(fileName string) (*Reader, error) {
@@ -33,68 +39,89 @@ func NewReader(fileName string) (*Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Reader{file}, nil
+
+	return NewReaderFromStorage(&fileStorage{file}), nil
+}
+
+// NewMemoryReader creates a Reader backed by an in-memory copy of data.
+func NewMemoryReader(data []byte) *Reader {
+	return NewReaderFromStorage(newMemoryStorage(data))
+}
+
+// NewBlobReader creates a Reader over the object stored at key in blob,
+// buffering it in memory for the lifetime of the Reader.
+func NewBlobReader(blob KVBlob, key string) (*Reader, error) {
+	storage, err := newKVBlobStorage(blob, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReaderFromStorage(storage), nil
 }
 
 func (r *Reader) IsValid() bool {
-	return r.file != nil
+	return r.storage != nil
 }
 
 func (r *Reader) ReadUInt32() (uint32, error) {
 	var value uint32
-	err := binary.Read(r.file, binary.LittleEndian, &value)
+	err := binary.Read(r, binary.LittleEndian, &value)
+
 	return value, err
 }
 
 func (r *Reader) ReadUInt64() (uint64, error) {
 	var value uint64
-	err := binary.Read(r.file, binary.LittleEndian, &value)
+	err := binary.Read(r, binary.LittleEndian, &value)
+
 	return value, err
 }
 
 func (r *Reader) Read(data []byte) (int, error) {
-	return r.file.Read(data)
+	n, err := r.storage.ReadAt(data, r.pos)
+	r.pos += int64(n)
+
+	return n, err
 }
 
 func (r *Reader) ReadChar() (byte, error) {
 	var value byte
-	err := binary.Read(r.file, binary.LittleEndian, &value)
+	err := binary.Read(r, binary.LittleEndian, &value)
+
 	return value, err
 }
 
 func (r *Reader) Seek(position int64, whence int) (int64, error) {
-	return r.file.Seek(position, whence)
+	pos, err := r.storage.Seek(position, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	r.pos = pos
+
+	return pos, nil
 }
 
 func (r *Reader) SeekFromBeginning(position int64) (int64, error) {
-	return r.file.Seek(position, io.SeekStart)
+	return r.Seek(position, io.SeekStart)
 }
 
 func (r *Reader) SeekFromEnd(position int64) (int64, error) {
-	return r.file.Seek(position, io.SeekEnd)
+	return r.Seek(position, io.SeekEnd)
 }
 
 func (r *Reader) SeekFromCurrent(position int64) (int64, error) {
-	return r.file.Seek(position, io.SeekCurrent)
+	return r.Seek(position, io.SeekCurrent)
 }
 
 func (r *Reader) Position() (int64, error) {
-	return r.file.Seek(0, io.SeekCurrent)
+	return r.Seek(0, io.SeekCurrent)
 }
 
 func (r *Reader) Size() (int64, error) {
-	currentPos, err := r.file.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return 0, err
-	}
-	defer r.file.Seek(currentPos, io.SeekStart)
-	fileSize, err := r.file.Seek(0, io.SeekEnd)
-	if err != nil {
-		return 0, err
-	}
-	return fileSize, nil
+	return r.storage.Size()
 }
 
 func (r *Reader) Close() error {
-	return r.file.Close()
+	return r.storage.Close()
 }
@@ -0,0 +1,201 @@
+/*
+ * readwrite
+ * Copyright (C) 2024 readwrite contributors
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package readwrite
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	errNegativeOffset = errors.New("negative offset")
+	errInvalidWhence  = errors.New("invalid whence")
+)
+
+// ErrBlobNotFound is the sentinel a KVBlob.Get implementation should
+// return (optionally wrapped, see errors.Is) when key does not exist.
+// newKVBlobStorage treats it as an empty object rather than a failure.
+var ErrBlobNotFound = errors.New("readwrite: blob not found")
+
+// Storage is the backend a Reader or Writer operates on. Implementations
+// must support random access (ReadAt/WriteAt) plus a cursor (Seek) so the
+// existing Position/Size/sequential-read helpers keep working unchanged
+// regardless of what actually stores the bytes.
+type Storage interface {
+	io.ReaderAt
+	io.WriterAt
+	io.Seeker
+	io.Closer
+	Size() (int64, error)
+}
+
+// fileStorage adapts *os.File to Storage. os.File already implements
+// ReadAt/WriteAt/Seek/Close; only Size is missing.
+type fileStorage struct {
+	*os.File
+}
+
+func (f *fileStorage) Size() (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// memoryStorage is a growable in-memory Storage backend.
+type memoryStorage struct {
+	buf []byte
+	pos int64
+}
+
+func newMemoryStorage(data []byte) *memoryStorage {
+	buf := make([]byte, len(data))
+	copy(buf, data)
+
+	return &memoryStorage{buf: buf}
+}
+
+func (m *memoryStorage) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	}
+
+	if off >= int64(len(m.buf)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.buf[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (m *memoryStorage) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	}
+
+	end := off + int64(len(p))
+	if end > int64(len(m.buf)) {
+		grown := make([]byte, end)
+		copy(grown, m.buf)
+		m.buf = grown
+	}
+
+	return copy(m.buf[off:], p), nil
+}
+
+func (m *memoryStorage) Seek(offset int64, whence int) (int64, error) {
+	var newPos int64
+
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = m.pos + offset
+	case io.SeekEnd:
+		newPos = int64(len(m.buf)) + offset
+	default:
+		return 0, errInvalidWhence
+	}
+
+	if newPos < 0 {
+		return 0, errNegativeOffset
+	}
+
+	m.pos = newPos
+
+	return m.pos, nil
+}
+
+func (m *memoryStorage) Close() error {
+	return nil
+}
+
+func (m *memoryStorage) Size() (int64, error) {
+	return int64(len(m.buf)), nil
+}
+
+// Bytes returns the backing buffer. Used by kvBlobStorage to flush on Close.
+func (m *memoryStorage) Bytes() []byte {
+	return m.buf
+}
+
+// KVBlob is the shape a cloud object store (S3, GCS, ...) is expected to
+// expose. kvBlobStorage layers Storage on top of it by buffering the
+// object in memory and flushing it back on Close, the same way object
+// store SDKs are typically fronted by a local staging buffer. Get must
+// return ErrBlobNotFound (directly or wrapped) when key does not exist,
+// mirroring how S3's GetObject/GCS's ObjectHandle.NewReader report a
+// missing object as an error rather than empty bytes.
+type KVBlob interface {
+	Get(key string) ([]byte, error)
+	Put(key string, data []byte) error
+	Stat(key string) (int64, error)
+}
+
+type kvBlobStorage struct {
+	blob  KVBlob
+	key   string
+	mem   *memoryStorage
+	dirty bool
+}
+
+func newKVBlobStorage(blob KVBlob, key string) (*kvBlobStorage, error) {
+	data, err := blob.Get(key)
+	if err != nil && !errors.Is(err, ErrBlobNotFound) {
+		return nil, err
+	}
+
+	return &kvBlobStorage{blob: blob, key: key, mem: newMemoryStorage(data)}, nil
+}
+
+func (k *kvBlobStorage) ReadAt(p []byte, off int64) (int, error) {
+	return k.mem.ReadAt(p, off)
+}
+
+func (k *kvBlobStorage) WriteAt(p []byte, off int64) (int, error) {
+	n, err := k.mem.WriteAt(p, off)
+	if n > 0 {
+		k.dirty = true
+	}
+
+	return n, err
+}
+
+func (k *kvBlobStorage) Seek(offset int64, whence int) (int64, error) {
+	return k.mem.Seek(offset, whence)
+}
+
+func (k *kvBlobStorage) Size() (int64, error) {
+	return k.mem.Size()
+}
+
+func (k *kvBlobStorage) Close() error {
+	if !k.dirty {
+		return nil
+	}
+
+	return k.blob.Put(k.key, k.mem.Bytes())
+}
@@ -25,7 +25,8 @@ import (
 )
 
 type Writer struct {
-	file *os.File
+	storage Storage
+	pos     int64
 }
 
 type FileEntry struct {
@@ -34,6 +35,15 @@ type FileEntry struct {
 	FileNameUpper uint32
 	Offset        uint64
 	UncompSize    uint64
+	Flags         uint32
+	// Compression identifies the per-entry compression codec (see
+	// readwrite/pack's CompressionNone/Zlib/Zstd) applied to the stored
+	// payload, if any.
+	Compression uint32
+	// StoredSize is the number of bytes actually present in the
+	// container for this entry. It equals UncompSize when Compression
+	// is CompressionNone, and the compressed byte count otherwise.
+	StoredSize uint64
 }
 
 type DataEntry struct {
@@ -59,69 +69,114 @@ func FindByFileName(data []DataEntry, fileName string) *DataEntry {
 	return nil
 }
 
+// NewWriterFromStorage wraps an arbitrary Storage backend in a Writer.
+func NewWriterFromStorage(storage Storage) *Writer {
+	return &Writer{storage: storage}
+}
+
 func NewWriter(fileName string, append bool) (*Writer, error) {
 	var file *os.File
 	var err error
 	if append {
-		file, err = os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		file, err = os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0644)
 	} else {
-		file, err = os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		file, err = os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &Writer{file}, nil
+
+	writer := NewWriterFromStorage(&fileStorage{file})
+	if append {
+		if _, err := writer.SeekFromEnd(0); err != nil {
+			file.Close()
+
+			return nil, err
+		}
+	}
+
+	return writer, nil
+}
+
+// NewMemoryWriter creates a Writer backed by a growable in-memory buffer.
+func NewMemoryWriter() *Writer {
+	return NewWriterFromStorage(newMemoryStorage(nil))
+}
+
+// NewBlobWriter creates a Writer over the object stored at key in blob.
+// The object is fetched (or treated as empty, if absent) and flushed back
+// to blob when the Writer is closed.
+func NewBlobWriter(blob KVBlob, key string) (*Writer, error) {
+	storage, err := newKVBlobStorage(blob, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterFromStorage(storage), nil
 }
 
 func (w *Writer) WriteUInt32(value uint32) error {
-	return binary.Write(w.file, binary.LittleEndian, value)
+	return binary.Write(w, binary.LittleEndian, value)
 }
 
 func (w *Writer) WriteUInt64(value uint64) error {
-	return binary.Write(w.file, binary.LittleEndian, value)
+	return binary.Write(w, binary.LittleEndian, value)
 }
 
 func (w *Writer) Write(data []byte) (int, error) {
-	return w.file.Write(data)
+	n, err := w.storage.WriteAt(data, w.pos)
+	w.pos += int64(n)
+
+	return n, err
 }
 
 func (w *Writer) WriteChar(data string) (int, error) {
-	return w.file.WriteString(data)
+	return w.Write([]byte(data))
 }
 
 func (w *Writer) Seek(position int64, whence int) (int64, error) {
-	return w.file.Seek(position, whence)
+	pos, err := w.storage.Seek(position, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	w.pos = pos
+
+	return pos, nil
 }
 
 func (w *Writer) SeekFromBeginning(position int64) (int64, error) {
-	return w.file.Seek(position, io.SeekStart)
+	return w.Seek(position, io.SeekStart)
 }
 
 func (w *Writer) SeekFromEnd(position int64) (int64, error) {
-	return w.file.Seek(position, io.SeekEnd)
+	return w.Seek(position, io.SeekEnd)
 }
 
 func (w *Writer) SeekFromCurrent(position int64) (int64, error) {
-	return w.file.Seek(position, io.SeekCurrent)
+	return w.Seek(position, io.SeekCurrent)
 }
 
 func (w *Writer) Position() (int64, error) {
-	return w.file.Seek(0, io.SeekCurrent)
+	return w.Seek(0, io.SeekCurrent)
 }
 
 func (w *Writer) Size() (int64, error) {
-	currentPos, err := w.file.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return 0, err
-	}
-	defer w.file.Seek(currentPos, io.SeekStart)
-	fileSize, err := w.file.Seek(0, io.SeekEnd)
-	if err != nil {
-		return 0, err
-	}
-	return fileSize, nil
+	return w.storage.Size()
 }
 
 func (w *Writer) Close() error {
-	return w.file.Close()
+	return w.storage.Close()
+}
+
+// Bytes returns the bytes written so far, for Writers whose Storage
+// backend can hand its buffer back out (NewMemoryWriter's memoryStorage).
+// It returns false for backends, such as fileStorage, that can't.
+func (w *Writer) Bytes() ([]byte, bool) {
+	b, ok := w.storage.(interface{ Bytes() []byte })
+	if !ok {
+		return nil, false
+	}
+
+	return b.Bytes(), true
 }
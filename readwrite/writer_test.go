@@ -0,0 +1,154 @@
+package readwrite
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMemoryWriterReaderRoundTrip(t *testing.T) {
+	w := NewMemoryWriter()
+
+	if _, err := w.WriteChar("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	written, ok := w.Bytes()
+	if !ok {
+		t.Fatal("Bytes() reported no backing buffer for a memory writer")
+	}
+
+	if !bytes.Equal(written, []byte("hello")) {
+		t.Fatalf("written = %q, want %q", written, "hello")
+	}
+
+	r := NewMemoryReader(written)
+
+	got := make([]byte, len(written))
+	if _, err := r.Read(got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, []byte("hello")) {
+		t.Fatalf("read back = %q, want %q", got, "hello")
+	}
+}
+
+type fakeKVBlob struct {
+	objects map[string][]byte
+}
+
+func newFakeKVBlob() *fakeKVBlob {
+	return &fakeKVBlob{objects: make(map[string][]byte)}
+}
+
+func (b *fakeKVBlob) Get(key string) ([]byte, error) {
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+
+	return data, nil
+}
+
+func (b *fakeKVBlob) Put(key string, data []byte) error {
+	b.objects[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (b *fakeKVBlob) Stat(key string) (int64, error) {
+	return int64(len(b.objects[key])), nil
+}
+
+func TestBlobWriterReaderRoundTrip(t *testing.T) {
+	blob := newFakeKVBlob()
+
+	w, err := NewBlobWriter(blob, "entry.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteChar("blob contents"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewBlobReader(blob, "entry.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, len("blob contents"))
+	if _, err := r.Read(got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, []byte("blob contents")) {
+		t.Fatalf("got %q, want %q", got, "blob contents")
+	}
+}
+
+// errKVBlobGetFailed is a stand-in for a real backend failure (e.g. a
+// network error or permission denial), distinct from a missing key.
+var errKVBlobGetFailed = errors.New("fakeKVBlob: Get failed")
+
+type failingKVBlob struct{}
+
+func (failingKVBlob) Get(string) ([]byte, error) { return nil, errKVBlobGetFailed }
+func (failingKVBlob) Put(string, []byte) error   { return nil }
+func (failingKVBlob) Stat(string) (int64, error) { return 0, nil }
+
+func TestNewBlobWriterPropagatesNonNotFoundError(t *testing.T) {
+	_, err := NewBlobWriter(failingKVBlob{}, "entry.bin")
+	if !errors.Is(err, errKVBlobGetFailed) {
+		t.Fatalf("got err %v, want %v", err, errKVBlobGetFailed)
+	}
+}
+
+func TestNewWriterAppend(t *testing.T) {
+	path := t.TempDir() + "/append.bin"
+
+	w, err := NewWriter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteChar("abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = NewWriter(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteChar("def"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, []byte("abcdef")) {
+		t.Fatalf("unexpected contents: %q", got)
+	}
+}